@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func init() {
+	RegisterCustomURLResolver([]string{"imgur.com"}, alwaysMatch, resolveImgur)
+	RegisterCustomURLResolver([]string{"clips.twitch.tv"}, alwaysMatch, resolveTwitchClip)
+	RegisterCustomURLResolver([]string{"wikipedia.org"}, alwaysMatch, resolveWikipedia)
+	// Twitter/X intentionally has no bespoke scraper here: it's served by the oEmbed-based
+	// resolver in oembed_resolver.go, which hits Twitter's official publish.twitter.com/oembed
+	// endpoint instead of scraping the page.
+}
+
+func alwaysMatch(resp *http.Response) bool {
+	return true
+}
+
+func resolveImgur(resp *http.Response) (*LinkResolverResponse, error) {
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	title := doc.Find(`meta[property="og:title"]`).AttrOr("content", "")
+	if title == "" {
+		return noLinkInfoFound, nil
+	}
+	description := doc.Find(`meta[property="og:description"]`).AttrOr("content", "")
+
+	tooltip := fmt.Sprintf("<div style=\"text-align: left;\"><b>%s</b>", html.EscapeString(title))
+	if description != "" {
+		tooltip += fmt.Sprintf("<hr>%s", html.EscapeString(description))
+	}
+	tooltip += "</div>"
+
+	return &LinkResolverResponse{
+		Status:  resp.StatusCode,
+		Tooltip: tooltip,
+		Link:    resp.Request.URL.String(),
+	}, nil
+}
+
+func resolveTwitchClip(resp *http.Response) (*LinkResolverResponse, error) {
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	title := doc.Find(`meta[property="og:title"]`).AttrOr("content", "")
+	if title == "" {
+		return noLinkInfoFound, nil
+	}
+
+	return &LinkResolverResponse{
+		Status:  resp.StatusCode,
+		Tooltip: fmt.Sprintf("<div style=\"text-align: left;\"><b>%s</b></div>", html.EscapeString(title)),
+		Link:    resp.Request.URL.String(),
+	}, nil
+}
+
+func resolveWikipedia(resp *http.Response) (*LinkResolverResponse, error) {
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	title := doc.Find("#firstHeading").First().Text()
+	summary := doc.Find("#mw-content-text p").Not(".mw-empty-elt").First().Text()
+
+	if title == "" {
+		return noLinkInfoFound, nil
+	}
+
+	tooltip := fmt.Sprintf("<div style=\"text-align: left;\"><b>%s</b>", html.EscapeString(title))
+	if summary != "" {
+		tooltip += fmt.Sprintf("<hr>%s", html.EscapeString(summary))
+	}
+	tooltip += "</div>"
+
+	return &LinkResolverResponse{
+		Status:  resp.StatusCode,
+		Tooltip: tooltip,
+		Link:    resp.Request.URL.String(),
+	}, nil
+}
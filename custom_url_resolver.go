@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// customURLResolver describes a registered handler for one or more hosts. check is
+// consulted first; if it returns true, run builds the tooltip response for the request.
+type customURLResolver struct {
+	hosts       []string
+	resolvedURL *regexp.Regexp
+	check       func(resp *http.Response) bool
+	run         func(resp *http.Response) (*LinkResolverResponse, error)
+}
+
+// customURLResolversByHost indexes registered resolvers by the exact host they were
+// registered for. Dispatch walks up through parent domains (e.g. "clips.twitch.tv" ->
+// "twitch.tv" -> "tv"), so lookup cost is bounded by the number of labels in the host
+// rather than the number of registered resolvers.
+var customURLResolversByHost = make(map[string][]*customURLResolver)
+
+// RegisterCustomURLResolver registers a handler for one or more hosts. A registration for
+// "twitter.com" also matches "mobile.twitter.com" and other subdomains.
+func RegisterCustomURLResolver(hosts []string, check func(resp *http.Response) bool, run func(resp *http.Response) (*LinkResolverResponse, error)) {
+	registerCustomURLResolver(&customURLResolver{
+		hosts: hosts,
+		check: check,
+		run:   run,
+	})
+}
+
+// RegisterCustomURLResolverForPattern is like RegisterCustomURLResolver, but additionally
+// requires the final resolved URL (after redirects) to match resolvedURLPattern. This lets
+// a single host route to different resolvers depending on the path, e.g. YouTube's
+// "/watch?v=" vs "/embed/" URLs.
+func RegisterCustomURLResolverForPattern(hosts []string, resolvedURLPattern *regexp.Regexp, check func(resp *http.Response) bool, run func(resp *http.Response) (*LinkResolverResponse, error)) {
+	registerCustomURLResolver(&customURLResolver{
+		hosts:       hosts,
+		resolvedURL: resolvedURLPattern,
+		check:       check,
+		run:         run,
+	})
+}
+
+func registerCustomURLResolver(resolver *customURLResolver) {
+	for _, host := range resolver.hosts {
+		customURLResolversByHost[host] = append(customURLResolversByHost[host], resolver)
+	}
+}
+
+// findCustomURLResolver returns the first registered resolver willing to handle resp, or
+// nil if none match.
+func findCustomURLResolver(resp *http.Response) *customURLResolver {
+	host := resp.Request.URL.Host
+
+	for {
+		for _, resolver := range customURLResolversByHost[host] {
+			if resolver.resolvedURL != nil && !resolver.resolvedURL.MatchString(resp.Request.URL.String()) {
+				continue
+			}
+			if resolver.check(resp) {
+				return resolver
+			}
+		}
+
+		idx := strings.Index(host, ".")
+		if idx == -1 {
+			return nil
+		}
+		host = host[idx+1:]
+	}
+}
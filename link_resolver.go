@@ -2,21 +2,42 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"html"
+	"io"
 	"net/http"
 	"net/url"
-	"path"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 )
 
+// upstreamTimeoutFlag bounds how long a single upstream fetch (the page itself, the
+// YouTube/Piped API call behind it, or an oEmbed lookup) is allowed to take before the
+// resolver gives up on it. Configurable so operators can tune it for slow networks.
+var upstreamTimeoutFlag = flag.Duration("upstream-timeout", 10*time.Second, "timeout for upstream HTTP fetches made by the link resolver")
+
+// maxUpstreamBodyBytes caps how much of an upstream response body is read, so a
+// misbehaving or huge page can't blow up memory.
+const maxUpstreamBodyBytes = 5 * 1024 * 1024
+
+var upstreamTimeoutResponse = &LinkResolverResponse{
+	Status:  504,
+	Message: "Upstream request timed out",
+}
+
+var requestCancelledResponse = &LinkResolverResponse{
+	Status:  503,
+	Message: "Request cancelled",
+}
+
 type LinkResolverResponse struct {
 	Status  int    `json:"status"`
 	Message string `json:"message,omitempty"`
@@ -74,77 +95,57 @@ func insertCommas(str string, n int) string {
 	return buffer.String()
 }
 
-var linkResolverRequestsMutex sync.Mutex
-var linkResolverRequests = make(map[string][](chan interface{}))
+var linkResolverGroup singleflight.Group
 
-type customURLManager struct {
-	check func(resp *http.Response) bool
-	run   func(resp *http.Response) ([]byte, error)
+// cappedBody wraps a response body so reads beyond limit return io.EOF, while Close still
+// closes the underlying body.
+type cappedBody struct {
+	io.Reader
+	io.Closer
 }
 
-var (
-	customURLManagers []customURLManager
-)
+func capBody(rc io.ReadCloser, limit int64) io.ReadCloser {
+	return cappedBody{Reader: io.LimitReader(rc, limit), Closer: rc}
+}
 
-func doRequest(url string) {
+func doRequest(url string) ([]byte, error) {
 	response := cacheGetOrSet("url:"+url, 10*time.Minute, func() (interface{}, error) {
-		resp, err := client.Get(url)
+		ctx, cancel := context.WithTimeout(context.Background(), *upstreamTimeoutFlag)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return json.Marshal(&LinkResolverResponse{Status: 500, Message: "invalid request " + err.Error()})
+		}
+
+		upstreamStart := time.Now()
+		resp, err := client.Do(req)
+		linkResolverUpstreamDuration.Observe(time.Since(upstreamStart).Seconds())
 		if err != nil {
 			if strings.HasSuffix(err.Error(), "no such host") {
 				return json.Marshal(noLinkInfoFound)
 			}
+			if ctx.Err() != nil {
+				return json.Marshal(upstreamTimeoutResponse)
+			}
 
 			return json.Marshal(&LinkResolverResponse{Status: 500, Message: "client.Get " + err.Error()})
 		}
 		defer resp.Body.Close()
+		resp.Body = capBody(resp.Body, maxUpstreamBodyBytes)
 
 		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-			doc, err := goquery.NewDocumentFromReader(resp.Body)
-			if err != nil {
-				return json.Marshal(&LinkResolverResponse{Status: 500, Message: "html parser error " + err.Error()})
-			}
-			if strings.HasSuffix(resp.Request.URL.Host, ".youtube.com") {
-				// do special youtube parsing
-
-				url := resp.Request.URL
-				videoID := ""
-
-				if strings.Index(url.Path, "embed") == -1 {
-					videoID = url.Query().Get("v")
-				} else {
-					videoID = path.Base(url.Path)
+			if resolver := findCustomURLResolver(resp); resolver != nil {
+				result, err := resolver.run(resp)
+				if err != nil {
+					return json.Marshal(&LinkResolverResponse{Status: 500, Message: "custom resolver error " + err.Error()})
 				}
-
-				if videoID == "" {
-					return json.Marshal(noLinkInfoFound)
-				}
-
-				youtubeResponse := cacheGetOrSet("youtube:"+videoID, 1*time.Hour, func() (interface{}, error) {
-					video, err := getYoutubeVideo(videoID)
-					if err != nil {
-						return &LinkResolverResponse{Status: 500, Message: "youtube api error " + err.Error()}, nil
-					}
-
-					fmt.Println("Doing YouTube API Request on", videoID)
-					return &LinkResolverResponse{
-						Status: resp.StatusCode,
-						Tooltip: "<div style=\"text-align: left;\"><b>" + html.EscapeString(video.Snippet.Title) +
-							"</b><hr><b>Channel:</b> " + html.EscapeString(video.Snippet.ChannelTitle) +
-							"<br><b>Duration:</b> " + html.EscapeString(formatDuration(video.ContentDetails.Duration)) +
-							"<br><b>Views:</b> " + insertCommas(strconv.FormatUint(video.Statistics.ViewCount, 10), 3) +
-							"<br><b>Likes:</b> <span style=\"color: green;\">+" + insertCommas(strconv.FormatUint(video.Statistics.LikeCount, 10), 3) +
-							"</span>/<span style=\"color: red;\">-" + insertCommas(strconv.FormatUint(video.Statistics.DislikeCount, 10), 3) +
-							"</span></div>",
-					}, nil
-				})
-
-				return json.Marshal(youtubeResponse)
+				return json.Marshal(result)
 			}
 
-			for _, m := range customURLManagers {
-				if m.check(resp) {
-					return m.run(resp)
-				}
+			doc, err := goquery.NewDocumentFromReader(resp.Body)
+			if err != nil {
+				return json.Marshal(&LinkResolverResponse{Status: 500, Message: "html parser error " + err.Error()})
 			}
 
 			escapedTitle := doc.Find("title").First().Text()
@@ -161,64 +162,102 @@ func doRequest(url string) {
 		return json.Marshal(noLinkInfoFound)
 	})
 
-	linkResolverRequestsMutex.Lock()
-	fmt.Println("Notify channels")
-	for _, channel := range linkResolverRequests[url] {
-		fmt.Printf("Notify channel %v\n", channel)
-		/*
-			select {
-			case channel <- response:
-				fmt.Println("hehe")
-			default:
-				fmt.Println("Unable to respond")
-			}
-		*/
-		channel <- response
+	data, ok := response.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected cached response type for %s", url)
 	}
-	delete(linkResolverRequests, url)
-	linkResolverRequestsMutex.Unlock()
+	return data, nil
 }
 
-func linkResolver(w http.ResponseWriter, r *http.Request) {
-	url, err := unescapeURLArgument(r, "url")
+func writeLinkResolverResponse(w http.ResponseWriter, response *LinkResolverResponse) {
+	data, err := json.Marshal(response)
 	if err != nil {
-		bytes, err := json.Marshal(invalidURL)
-		if err != nil {
-			fmt.Println("Error marshalling invalidURL struct:", err)
-			return
-		}
-		_, err = w.Write(bytes)
-		if err != nil {
-			fmt.Println("Error in w.Write:", err)
-		}
+		logrus.WithError(err).Error("failed to marshal LinkResolverResponse")
 		return
 	}
+	writeRawResponse(w, data)
+}
+
+func writeRawResponse(w http.ResponseWriter, data []byte) {
+	if _, err := w.Write(data); err != nil {
+		logrus.WithError(err).Error("failed to write link resolver response")
+	}
+}
 
-	cacheKey := "url:" + url
+// classifyResult labels a resolved response for the linkresolver_requests_total metric,
+// distinguishing a confirmed "no link info found" response (404) from an ordinary cache hit
+// or miss so operators can tell real upstream misses apart from already-known dead links.
+func classifyResult(data []byte, cacheHit bool) string {
+	var parsed LinkResolverResponse
+	if err := json.Unmarshal(data, &parsed); err == nil && parsed.Status == http.StatusNotFound {
+		return "404"
+	}
+	if cacheHit {
+		return "hit"
+	}
+	return "miss"
+}
 
-	var response interface{}
+func linkResolver(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
 
-	if data := cacheGet(cacheKey); data != nil {
-		response = data
-	} else {
-		responseChannel := make(chan interface{})
-
-		linkResolverRequestsMutex.Lock()
-		linkResolverRequests[url] = append(linkResolverRequests[url], responseChannel)
-		urlRequestsLength := len(linkResolverRequests[url])
-		linkResolverRequestsMutex.Unlock()
-		if urlRequestsLength == 1 {
-			// First poll for this URL, start the request!
-			go doRequest(url)
-		}
+	requestedURL, err := unescapeURLArgument(r, "url")
+	if err != nil {
+		linkResolverRequestsTotal.WithLabelValues("error").Inc()
+		writeLinkResolverResponse(w, invalidURL)
+		return
+	}
+
+	log := logrus.WithField("url", requestedURL)
+	cacheKey := "url:" + requestedURL
 
-		fmt.Printf("Listening to channel %v\n", responseChannel)
-		response = <-responseChannel
-		fmt.Println("got response!")
+	if data := cacheGet(cacheKey); data != nil {
+		cached := data.([]byte)
+		linkResolverRequestsTotal.WithLabelValues(classifyResult(cached, true)).Inc()
+		log.WithField("latency", time.Since(start)).Debug("resolved link (cache hit)")
+		writeRawResponse(w, cached)
+		return
 	}
 
-	_, err = w.Write(response.([]byte))
-	if err != nil {
-		fmt.Println("Error in w.Write:", err)
+	type fetchResult struct {
+		data []byte
+		err  error
+	}
+	done := make(chan fetchResult, 1)
+
+	go func() {
+		leader := false
+		data, err, shared := linkResolverGroup.Do(requestedURL, func() (interface{}, error) {
+			leader = true
+			return doRequest(requestedURL)
+		})
+		if shared && !leader {
+			linkResolverSingleflightCoalesced.Inc()
+		}
+		if err != nil {
+			done <- fetchResult{err: err}
+			return
+		}
+		done <- fetchResult{data: data.([]byte)}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			linkResolverRequestsTotal.WithLabelValues("error").Inc()
+			log.WithError(result.err).WithField("latency", time.Since(start)).Warn("link resolver upstream error")
+			writeLinkResolverResponse(w, &LinkResolverResponse{Status: 500, Message: "link resolver error " + result.err.Error()})
+			return
+		}
+		linkResolverRequestsTotal.WithLabelValues(classifyResult(result.data, false)).Inc()
+		log.WithField("latency", time.Since(start)).Debug("resolved link (cache miss)")
+		writeRawResponse(w, result.data)
+	case <-r.Context().Done():
+		// The client disconnected or their request timed out while we were still waiting
+		// on the (possibly shared) upstream fetch; that fetch keeps running in the
+		// background for any other callers still waiting on it.
+		linkResolverRequestsTotal.WithLabelValues("error").Inc()
+		log.WithField("latency", time.Since(start)).Debug("link resolver request cancelled by client")
+		writeLinkResolverResponse(w, requestCancelledResponse)
 	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestLinkResolverCoalescesConcurrentRequests fires N concurrent requests for the same URL
+// and asserts that exactly one upstream fetch happens, and that every caller gets a
+// response even when some of them cancel mid-flight.
+func TestLinkResolverCoalescesConcurrentRequests(t *testing.T) {
+	var upstreamHits int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&upstreamHits, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("<html><head><title>Test Page</title></head></html>"))
+	}))
+	defer upstream.Close()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/link_resolver/{url}", linkResolver)
+
+	const callers = 10
+	const cancelled = 3
+
+	var wg sync.WaitGroup
+	bodies := make([][]byte, callers)
+
+	escapedURL := url.PathEscape(upstream.URL)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/link_resolver/"+escapedURL, nil)
+			if i < cancelled {
+				ctx, cancel := context.WithTimeout(req.Context(), time.Millisecond)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			bodies[i] = rec.Body.Bytes()
+		}(i)
+	}
+
+	wg.Wait()
+
+	if hits := atomic.LoadInt32(&upstreamHits); hits != 1 {
+		t.Errorf("expected exactly 1 upstream fetch, got %d", hits)
+	}
+
+	for i, body := range bodies {
+		if len(body) == 0 {
+			t.Errorf("caller %d never received a response body", i)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logLevelFlag = flag.String("log-level", "info", "logging verbosity: debug, info, warn, error")
+
+// configureLogging applies the -log-level flag to logrus's global logger. Call this once
+// from main() after flag.Parse().
+func configureLogging() {
+	level, err := logrus.ParseLevel(*logLevelFlag)
+	if err != nil {
+		logrus.WithError(err).Warn("invalid -log-level, defaulting to info")
+		level = logrus.InfoLevel
+	}
+	logrus.SetLevel(level)
+}
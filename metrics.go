@@ -0,0 +1,46 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	linkResolverRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "linkresolver_requests_total",
+		Help: "Total number of link resolver requests, partitioned by result.",
+	}, []string{"result"})
+
+	linkResolverUpstreamDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "linkresolver_upstream_duration_seconds",
+		Help: "Duration of upstream HTTP fetches made by the link resolver.",
+	})
+
+	linkResolverSingleflightCoalesced = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "linkresolver_singleflight_coalesced_total",
+		Help: "Number of link resolver requests that were coalesced onto an in-flight upstream fetch.",
+	})
+
+	youtubeAPICallsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "youtube_api_calls_total",
+		Help: "Total number of requests made to the YouTube Data API.",
+	})
+
+	// linkResolverCacheEntries reports the live entry count of the response cache so
+	// operators can watch for unbounded growth. cacheLen is expected to be provided by the
+	// cache package alongside cacheGet/cacheGetOrSet.
+	linkResolverCacheEntries = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "linkresolver_cache_entries",
+		Help: "Current number of entries in the link resolver response cache.",
+	}, func() float64 {
+		return float64(cacheLen())
+	})
+)
+
+// registerMetricsRoute wires up the /metrics endpoint scraped by Prometheus. Call this once
+// from main() alongside the rest of the route registration.
+func registerMetricsRoute(router *mux.Router) {
+	router.Handle("/metrics", promhttp.Handler())
+}
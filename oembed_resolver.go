@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// oembedCacheTTL is how long a fetched oEmbed response is cached for, longer than the base
+// URL cache since oEmbed metadata (title, author, thumbnail) rarely changes.
+const oembedCacheTTL = 24 * time.Hour
+
+// oembedSite maps a set of hosts to the oEmbed endpoint that serves them.
+type oembedSite struct {
+	hosts    []string
+	endpoint string
+}
+
+var oembedSites = []oembedSite{
+	{hosts: []string{"twitter.com", "x.com"}, endpoint: "https://publish.twitter.com/oembed"},
+	{hosts: []string{"soundcloud.com"}, endpoint: "https://soundcloud.com/oembed"},
+	{hosts: []string{"vimeo.com"}, endpoint: "https://vimeo.com/api/oembed.json"},
+	{hosts: []string{"reddit.com"}, endpoint: "https://www.reddit.com/oembed"},
+	{hosts: []string{"tiktok.com"}, endpoint: "https://www.tiktok.com/oembed"},
+}
+
+type oembedResponse struct {
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+func init() {
+	for _, site := range oembedSites {
+		endpoint := site.endpoint
+		RegisterCustomURLResolver(site.hosts, alwaysMatch, func(resp *http.Response) (*LinkResolverResponse, error) {
+			return resolveOembed(resp.Request.Context(), endpoint, resp.Request.URL.String())
+		})
+	}
+}
+
+func resolveOembed(ctx context.Context, endpoint, pageURL string) (*LinkResolverResponse, error) {
+	cached := cacheGetOrSet("oembed:"+endpoint+":"+pageURL, oembedCacheTTL, func() (interface{}, error) {
+		return fetchOembed(ctx, endpoint, pageURL)
+	})
+
+	result, ok := cached.(*LinkResolverResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected cached oembed value for %s", pageURL)
+	}
+	return result, nil
+}
+
+// fetchOembed is bounded by ctx — the same deadline as the page fetch that triggered this
+// resolver — so a hanging oEmbed endpoint can't stall callers waiting on the singleflight
+// fetch this runs underneath.
+func fetchOembed(ctx context.Context, endpoint, pageURL string) (interface{}, error) {
+	reqURL := endpoint + "?format=json&url=" + url.QueryEscape(pageURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return &LinkResolverResponse{Status: 500, Message: "oembed request error " + err.Error()}, nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &LinkResolverResponse{Status: 500, Message: "oembed request error " + err.Error()}, nil
+	}
+	defer resp.Body.Close()
+	resp.Body = capBody(resp.Body, maxUpstreamBodyBytes)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return noLinkInfoFound, nil
+	}
+
+	var data oembedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return &LinkResolverResponse{Status: 500, Message: "oembed decode error " + err.Error()}, nil
+	}
+
+	return &LinkResolverResponse{
+		Status:  200,
+		Tooltip: buildOembedTooltip(&data),
+		Link:    pageURL,
+	}, nil
+}
+
+func buildOembedTooltip(data *oembedResponse) string {
+	var b strings.Builder
+	b.WriteString("<div style=\"text-align: left;\">")
+
+	if data.Title != "" {
+		b.WriteString("<b>" + html.EscapeString(data.Title) + "</b><hr>")
+	}
+	if data.AuthorName != "" {
+		b.WriteString("<b>By:</b> " + html.EscapeString(data.AuthorName) + "<br>")
+	}
+	if data.ProviderName != "" {
+		b.WriteString("<b>Via:</b> " + html.EscapeString(data.ProviderName))
+	}
+	if data.Type == "photo" && data.ThumbnailURL != "" {
+		b.WriteString("<br><img src=\"" + html.EscapeString(data.ThumbnailURL) + "\">")
+	}
+
+	b.WriteString("</div>")
+	return b.String()
+}
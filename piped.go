@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pipedInstancesFlag overrides the default Piped instance list with a comma-separated list,
+// so operators can add their own instance (e.g. a self-hosted one) without a code change.
+var pipedInstancesFlag = flag.String("piped-instances", "", "comma-separated list of Piped instance base URLs to use as a YouTube fallback (defaults to a built-in public list)")
+
+// pipedInstances is the rotating list of public Piped instances queried as a fallback when
+// the YouTube Data API errors out or has no key configured. Overridden by -piped-instances
+// via configurePipedInstances, called once from main() after flag.Parse().
+var pipedInstances = []string{
+	"https://pipedapi.kavin.rocks",
+	"https://api.piped.yt",
+	"https://pipedapi.moomoo.me",
+}
+
+// configurePipedInstances applies the -piped-instances flag, replacing the default instance
+// list if the operator provided one. Call this once from main() after flag.Parse().
+func configurePipedInstances() {
+	if *pipedInstancesFlag == "" {
+		return
+	}
+
+	var instances []string
+	for _, instance := range strings.Split(*pipedInstancesFlag, ",") {
+		instance = strings.TrimSpace(instance)
+		if instance != "" {
+			instances = append(instances, instance)
+		}
+	}
+
+	if len(instances) > 0 {
+		pipedInstances = instances
+	}
+}
+
+// pipedInstanceCooldown is how long a Piped instance is skipped after it fails.
+const pipedInstanceCooldown = 12 * time.Hour
+
+var pipedInstanceNextIndex uint64
+var pipedInstanceFailures sync.Map // instance -> time.Time it can be retried again
+
+type pipedStreamsResponse struct {
+	Title      string `json:"title"`
+	Uploader   string `json:"uploader"`
+	Duration   int64  `json:"duration"`
+	Views      int64  `json:"views"`
+	UploadDate string `json:"uploadDate"`
+}
+
+// nextHealthyPipedInstance returns the next Piped instance to try in round-robin order,
+// skipping any still in their post-failure cooldown window.
+func nextHealthyPipedInstance() (string, bool) {
+	now := time.Now()
+	for i := 0; i < len(pipedInstances); i++ {
+		idx := int(atomic.AddUint64(&pipedInstanceNextIndex, 1)-1) % len(pipedInstances)
+		instance := pipedInstances[idx]
+
+		if retryAfter, ok := pipedInstanceFailures.Load(instance); ok {
+			if now.Before(retryAfter.(time.Time)) {
+				continue
+			}
+		}
+
+		return instance, true
+	}
+
+	return "", false
+}
+
+func markPipedInstanceFailed(instance string) {
+	pipedInstanceFailures.Store(instance, time.Now().Add(pipedInstanceCooldown))
+}
+
+// errPipedVideoNotFound means the instance answered but hasn't indexed this particular
+// video, as opposed to the instance itself being down or misbehaving. It should never count
+// against the instance's health, since every other video lookup shares the same pool.
+var errPipedVideoNotFound = fmt.Errorf("video not found on this piped instance")
+
+// getYoutubeVideoViaPiped fetches video metadata from a healthy Piped instance, making up
+// to one attempt per configured instance until one succeeds or all are exhausted/unhealthy. ctx bounds
+// each attempt so a hanging instance can't stall callers waiting on the singleflight fetch
+// it's nested under.
+func getYoutubeVideoViaPiped(ctx context.Context, videoID string) (*LinkResolverResponse, error) {
+	var lastErr error
+
+	for attempt := 0; attempt < len(pipedInstances); attempt++ {
+		instance, ok := nextHealthyPipedInstance()
+		if !ok {
+			break
+		}
+
+		result, err := fetchPipedStreams(ctx, instance, videoID)
+		if err != nil {
+			if err != errPipedVideoNotFound {
+				markPipedInstanceFailed(instance)
+			}
+			lastErr = err
+			continue
+		}
+
+		return result, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no healthy piped instances available")
+	}
+	return nil, lastErr
+}
+
+func fetchPipedStreams(ctx context.Context, instance, videoID string) (*LinkResolverResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, instance+"/streams/"+videoID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	resp.Body = capBody(resp.Body, maxUpstreamBodyBytes)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errPipedVideoNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("piped instance %s returned status %d", instance, resp.StatusCode)
+	}
+
+	var streams pipedStreamsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&streams); err != nil {
+		return nil, err
+	}
+
+	uploadDate := streams.UploadDate
+	if t, err := time.Parse("2006-01-02", uploadDate); err == nil {
+		uploadDate = t.Format("Jan 2, 2006")
+	}
+
+	return &LinkResolverResponse{
+		Status: 200,
+		Tooltip: "<div style=\"text-align: left;\"><b>" + html.EscapeString(streams.Title) +
+			"</b><hr><b>Channel:</b> " + html.EscapeString(streams.Uploader) +
+			"<br><b>Duration:</b> " + formatSecondsDuration(streams.Duration) +
+			"<br><b>Views:</b> " + insertCommas(strconv.FormatInt(streams.Views, 10), 3) +
+			"<br><b>Uploaded:</b> " + html.EscapeString(uploadDate) +
+			"</div>",
+	}, nil
+}
+
+// formatSecondsDuration formats a duration given in seconds as "HH:MM:SS", matching the
+// style of formatDuration which works off the YouTube Data API's ISO 8601 durations. Piped
+// reports -1 for ongoing livestreams, which have no fixed duration.
+func formatSecondsDuration(seconds int64) string {
+	if seconds < 0 {
+		return "LIVE"
+	}
+
+	d := time.Duration(seconds) * time.Second
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
@@ -0,0 +1,78 @@
+// Package youtube holds the parsed representation of a YouTube Data API video resource,
+// kept free of HTTP so the parsing and rendering logic can be unit tested independent of
+// the network.
+package youtube
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// VideoInfo is the subset of a videos.list response (parts: snippet, contentDetails,
+// statistics, liveStreamingDetails, status) that the tooltip renderer needs.
+type VideoInfo struct {
+	Snippet struct {
+		Title        string `json:"title"`
+		ChannelTitle string `json:"channelTitle"`
+		Description  string `json:"description"`
+	} `json:"snippet"`
+
+	ContentDetails struct {
+		Duration      string `json:"duration"`
+		ContentRating struct {
+			YtRating string `json:"ytRating"`
+		} `json:"contentRating"`
+	} `json:"contentDetails"`
+
+	Statistics struct {
+		ViewCount    uint64 `json:"viewCount,string"`
+		LikeCount    uint64 `json:"likeCount,string"`
+		DislikeCount uint64 `json:"dislikeCount,string"`
+	} `json:"statistics"`
+
+	LiveStreamingDetails struct {
+		ScheduledStartTime time.Time `json:"scheduledStartTime"`
+		ActualStartTime    time.Time `json:"actualStartTime"`
+		ActualEndTime      time.Time `json:"actualEndTime"`
+	} `json:"liveStreamingDetails"`
+}
+
+// Chapter is a single named timestamp parsed out of a video's description.
+type Chapter struct {
+	Timestamp string
+	Title     string
+}
+
+var chapterLine = regexp.MustCompile(`(?m)^(\d?\d:\d\d(?::\d\d)?)\s+(.+)$`)
+
+// Chapters parses chapter markers out of the video description, in the order they appear.
+func (v *VideoInfo) Chapters() []Chapter {
+	matches := chapterLine.FindAllStringSubmatch(v.Snippet.Description, -1)
+	chapters := make([]Chapter, 0, len(matches))
+	for _, m := range matches {
+		chapters = append(chapters, Chapter{Timestamp: m[1], Title: strings.TrimSpace(m[2])})
+	}
+	return chapters
+}
+
+// IsLive reports whether the video is currently being live-streamed.
+func (v *VideoInfo) IsLive() bool {
+	return !v.LiveStreamingDetails.ActualStartTime.IsZero() && v.LiveStreamingDetails.ActualEndTime.IsZero()
+}
+
+// IsUpcoming reports whether the video is a scheduled premiere that hasn't started yet.
+func (v *VideoInfo) IsUpcoming() bool {
+	return !v.LiveStreamingDetails.ScheduledStartTime.IsZero() && v.LiveStreamingDetails.ActualStartTime.IsZero()
+}
+
+// TimeUntilPremiere returns how long until a scheduled premiere goes live. Only meaningful
+// when IsUpcoming reports true.
+func (v *VideoInfo) TimeUntilPremiere() time.Duration {
+	return time.Until(v.LiveStreamingDetails.ScheduledStartTime)
+}
+
+// IsAgeRestricted reports whether YouTube has flagged the video as age-restricted.
+func (v *VideoInfo) IsAgeRestricted() bool {
+	return v.ContentDetails.ContentRating.YtRating == "ytAgeRestricted"
+}
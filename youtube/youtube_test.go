@@ -0,0 +1,72 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChapters(t *testing.T) {
+	v := &VideoInfo{}
+	v.Snippet.Description = "Intro\n0:00 Introduction\n1:23 Getting started\n12:34:56 Way too long\nSome other line"
+
+	chapters := v.Chapters()
+	if len(chapters) != 3 {
+		t.Fatalf("expected 3 chapters, got %d: %+v", len(chapters), chapters)
+	}
+
+	if chapters[0].Timestamp != "0:00" || chapters[0].Title != "Introduction" {
+		t.Errorf("unexpected first chapter: %+v", chapters[0])
+	}
+	if chapters[1].Timestamp != "1:23" || chapters[1].Title != "Getting started" {
+		t.Errorf("unexpected second chapter: %+v", chapters[1])
+	}
+	if chapters[2].Timestamp != "12:34:56" || chapters[2].Title != "Way too long" {
+		t.Errorf("unexpected third chapter: %+v", chapters[2])
+	}
+}
+
+func TestIsLive(t *testing.T) {
+	v := &VideoInfo{}
+	if v.IsLive() {
+		t.Error("zero-value video should not be live")
+	}
+
+	v.LiveStreamingDetails.ActualStartTime = time.Now().Add(-time.Hour)
+	if !v.IsLive() {
+		t.Error("video with an actual start time and no end time should be live")
+	}
+
+	v.LiveStreamingDetails.ActualEndTime = time.Now()
+	if v.IsLive() {
+		t.Error("video with an actual end time should no longer be live")
+	}
+}
+
+func TestIsUpcoming(t *testing.T) {
+	v := &VideoInfo{}
+	if v.IsUpcoming() {
+		t.Error("zero-value video should not be upcoming")
+	}
+
+	v.LiveStreamingDetails.ScheduledStartTime = time.Now().Add(2 * time.Hour)
+	if !v.IsUpcoming() {
+		t.Error("video with a scheduled start time and no actual start time should be upcoming")
+	}
+
+	v.LiveStreamingDetails.ActualStartTime = time.Now()
+	if v.IsUpcoming() {
+		t.Error("video that has started should no longer be upcoming")
+	}
+}
+
+func TestIsAgeRestricted(t *testing.T) {
+	v := &VideoInfo{}
+	if v.IsAgeRestricted() {
+		t.Error("zero-value video should not be age restricted")
+	}
+
+	v.ContentDetails.ContentRating.YtRating = "ytAgeRestricted"
+	if !v.IsAgeRestricted() {
+		t.Error("video with ytAgeRestricted rating should be age restricted")
+	}
+}
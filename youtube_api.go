@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/dnsge/chatterino-api/youtube"
+)
+
+// youtubeAPIKey is the YouTube Data API v3 key used by getYoutubeVideo. If empty,
+// getYoutubeVideo fails immediately and callers fall back to Piped.
+var youtubeAPIKey string
+
+const youtubeVideosEndpoint = "https://www.googleapis.com/youtube/v3/videos"
+
+// youtubeVideoParts lists every part getYoutubeVideo needs in one call to cover live
+// status, premiere scheduling, age restriction, and chapter/description parsing.
+const youtubeVideoParts = "snippet,contentDetails,statistics,liveStreamingDetails,status"
+
+type youtubeVideosListResponse struct {
+	Items []youtube.VideoInfo `json:"items"`
+}
+
+// getYoutubeVideo looks up a single video's metadata from the YouTube Data API. ctx bounds
+// the request so a slow/hanging API call can't stall callers waiting on the singleflight
+// fetch it's nested under.
+func getYoutubeVideo(ctx context.Context, videoID string) (*youtube.VideoInfo, error) {
+	if youtubeAPIKey == "" {
+		return nil, fmt.Errorf("no YouTube API key configured")
+	}
+
+	query := url.Values{}
+	query.Set("id", videoID)
+	query.Set("part", youtubeVideoParts)
+	query.Set("key", youtubeAPIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, youtubeVideosEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	resp.Body = capBody(resp.Body, maxUpstreamBodyBytes)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("youtube api returned status %d", resp.StatusCode)
+	}
+
+	var data youtubeVideosListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if len(data.Items) == 0 {
+		return nil, fmt.Errorf("video %s not found", videoID)
+	}
+
+	return &data.Items[0], nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// youtubeWatchPattern and youtubeEmbedPattern are the two URL shapes YouTube serves video
+// pages under; the video ID lives in a different place in each (query param vs path), so
+// both are registered against the "youtube.com" host via the pattern-matching registry
+// rather than handled by one-off inline parsing.
+var youtubeWatchPattern = regexp.MustCompile(`/watch`)
+var youtubeEmbedPattern = regexp.MustCompile(`/embed/`)
+
+func init() {
+	RegisterCustomURLResolverForPattern([]string{"youtube.com"}, youtubeWatchPattern, alwaysMatch, resolveYoutubeVideo)
+	RegisterCustomURLResolverForPattern([]string{"youtube.com"}, youtubeEmbedPattern, alwaysMatch, resolveYoutubeVideo)
+}
+
+func extractYoutubeVideoID(u *url.URL) string {
+	if strings.Contains(u.Path, "embed") {
+		return path.Base(u.Path)
+	}
+	return u.Query().Get("v")
+}
+
+func resolveYoutubeVideo(resp *http.Response) (*LinkResolverResponse, error) {
+	videoID := extractYoutubeVideoID(resp.Request.URL)
+	if videoID == "" {
+		return noLinkInfoFound, nil
+	}
+
+	// Reuse the context the outer page fetch was given, so a slow YouTube/Piped call can't
+	// outlive the upstream deadline doRequest already enforced.
+	ctx := resp.Request.Context()
+
+	cached := cacheGetOrSet("youtube:"+videoID, 1*time.Hour, func() (interface{}, error) {
+		if youtubeAPIKey != "" {
+			youtubeAPICallsTotal.Inc()
+			logrus.WithField("videoID", videoID).Debug("requesting YouTube API")
+		}
+
+		video, err := getYoutubeVideo(ctx, videoID)
+		if err != nil {
+			if fallback, fallbackErr := getYoutubeVideoViaPiped(ctx, videoID); fallbackErr == nil {
+				return fallback, nil
+			}
+			return &LinkResolverResponse{Status: 500, Message: "youtube api error " + err.Error()}, nil
+		}
+
+		return &LinkResolverResponse{
+			Status:  resp.StatusCode,
+			Tooltip: buildYoutubeTooltip(video),
+		}, nil
+	})
+
+	result, ok := cached.(*LinkResolverResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected cached youtube value for %s", videoID)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dnsge/chatterino-api/youtube"
+)
+
+// maxTooltipChapters caps how many chapters are listed in a video tooltip so it doesn't
+// grow unbounded for videos with long chapter lists.
+const maxTooltipChapters = 5
+
+// buildYoutubeTooltip renders a video's metadata, live/premiere status, age restriction,
+// and chapters into the HTML tooltip shown by Chatterino.
+func buildYoutubeTooltip(video *youtube.VideoInfo) string {
+	var b strings.Builder
+
+	b.WriteString("<div style=\"text-align: left;\"><b>")
+	b.WriteString(html.EscapeString(video.Snippet.Title))
+	b.WriteString("</b>")
+
+	switch {
+	case video.IsLive():
+		b.WriteString(" <span style=\"color: red;\">\U0001F534 LIVE</span>")
+	case video.IsUpcoming():
+		b.WriteString(" <span style=\"color: gray;\">Premieres in ")
+		b.WriteString(formatApprox(video.TimeUntilPremiere()))
+		b.WriteString("</span>")
+	}
+
+	if video.IsAgeRestricted() {
+		b.WriteString(" <span style=\"color: orange;\">[Age Restricted]</span>")
+	}
+
+	b.WriteString("<hr><b>Channel:</b> ")
+	b.WriteString(html.EscapeString(video.Snippet.ChannelTitle))
+	b.WriteString("<br><b>Duration:</b> ")
+	b.WriteString(html.EscapeString(formatDuration(video.ContentDetails.Duration)))
+	b.WriteString("<br><b>Views:</b> ")
+	b.WriteString(insertCommas(strconv.FormatUint(video.Statistics.ViewCount, 10), 3))
+	b.WriteString("<br><b>Likes:</b> <span style=\"color: green;\">+")
+	b.WriteString(insertCommas(strconv.FormatUint(video.Statistics.LikeCount, 10), 3))
+	b.WriteString("</span>/<span style=\"color: red;\">-")
+	b.WriteString(insertCommas(strconv.FormatUint(video.Statistics.DislikeCount, 10), 3))
+	b.WriteString("</span>")
+
+	if chapters := video.Chapters(); len(chapters) > 0 {
+		b.WriteString("<hr><b>Chapters:</b>")
+		for i, chapter := range chapters {
+			if i >= maxTooltipChapters {
+				break
+			}
+			b.WriteString("<br>")
+			b.WriteString(html.EscapeString(chapter.Timestamp))
+			b.WriteString(" ")
+			b.WriteString(html.EscapeString(chapter.Title))
+		}
+	}
+
+	b.WriteString("</div>")
+	return b.String()
+}
+
+// formatApprox renders a duration as a short approximate string, e.g. "2h 13m".
+func formatApprox(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}